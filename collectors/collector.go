@@ -0,0 +1,272 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	scrapeDurationDesc = prometheus.NewDesc(
+		"kube_state_metrics_scrape_duration_seconds",
+		"Duration of a collector scrape.",
+		[]string{"collector"}, nil,
+	)
+	scrapeSuccessDesc = prometheus.NewDesc(
+		"kube_state_metrics_scrape_success",
+		"Whether a collector succeeded.",
+		[]string{"collector"}, nil,
+	)
+	lastScrapeErrorDesc = prometheus.NewDesc(
+		"kube_state_metrics_last_scrape_error",
+		"Whether the last scrape of a collector resulted in an error (1 for error, 0 for success).",
+		[]string{"collector"}, nil,
+	)
+	collectorScopeDesc = prometheus.NewDesc(
+		"kube_state_metrics_collector_scope",
+		"The namespace scope a collector is watching ('cluster' or a comma-separated namespace list).",
+		[]string{"collector", "scope"}, nil,
+	)
+)
+
+// Collector is implemented by every kube-state-metrics subcollector. It is
+// deliberately smaller than prometheus.Collector: KubeStateCollector is the
+// only thing that actually gets registered with a prometheus.Registerer,
+// and it takes care of Describe and scrape instrumentation on behalf of
+// every Collector it wraps.
+type Collector interface {
+	// Name identifies the collector on the command line (--collector.<name>)
+	// and in scrape instrumentation labels.
+	Name() string
+	// Update sends the metrics this collector knows about on ch. An error
+	// is recorded against this collector alone; it does not stop the other
+	// collectors registered with the same KubeStateCollector from running.
+	Update(ch chan<- prometheus.Metric) error
+}
+
+// CollectorFactory builds a Collector for the given client, scoped by cfg.
+// It may return a nil Collector and a nil error to indicate that this
+// collector should be silently skipped (e.g. a cluster-scoped collector
+// asked to run against a namespace-restricted CollectorConfig).
+type CollectorFactory func(kubeClient kubernetes.Interface, cfg CollectorConfig) (Collector, error)
+
+type registeredCollector struct {
+	factory          CollectorFactory
+	isDefaultEnabled bool
+}
+
+// newNamespacedInformers starts one SharedInformer per namespace in
+// cfg.namespaces(), applying cfg's label/field selectors to each. This is
+// how every namespaced collector honors a CollectorConfig that names more
+// than one namespace: each entry gets its own list-watch, merged by the
+// caller when listing, so cfg.scope() never reports more than is actually
+// being watched.
+func newNamespacedInformers(kubeClient kubernetes.Interface, resource string, objType runtime.Object, cfg CollectorConfig) []cache.SharedInformer {
+	client := kubeClient.CoreV1().RESTClient()
+
+	namespaces := cfg.namespaces()
+	informers := make([]cache.SharedInformer, 0, len(namespaces))
+	for _, ns := range namespaces {
+		lw := cache.NewFilteredListWatchFromClient(client, resource, ns, func(options *metav1.ListOptions) {
+			options.LabelSelector = cfg.LabelSelector
+			options.FieldSelector = cfg.FieldSelector
+		})
+		sinf := cache.NewSharedInformer(lw, objType, resyncPeriod)
+		go sinf.Run(context.Background().Done())
+		informers = append(informers, sinf)
+	}
+	return informers
+}
+
+// collectorFlag is a tri-state flag.Value: unset, explicitly true, or
+// explicitly false. This lets --collectors.disable-defaults know whether a
+// given --collector.<name> flag was passed at all.
+type collectorFlag struct {
+	value *bool
+}
+
+func (f *collectorFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*f.value)
+}
+
+func (f *collectorFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	f.value = &v
+	return nil
+}
+
+func (f *collectorFlag) IsBoolFlag() bool { return true }
+
+var (
+	collectorFactoriesMu sync.Mutex
+	collectorFactories   = map[string]registeredCollector{}
+	collectorFlags       = map[string]*collectorFlag{}
+
+	disableDefaultCollectors = flag.Bool("collectors.disable-defaults", false,
+		"Set to true to disable all collectors enabled by default and only run those explicitly turned on with --collector.<name>.")
+)
+
+// registerCollector makes a collector known to the registry under name and
+// exposes a --collector.<name> flag for it. Collectors call this from an
+// init() so that main only ever has to build one KubeStateCollector.
+func registerCollector(name string, isDefaultEnabled bool, factory CollectorFactory) {
+	collectorFactoriesMu.Lock()
+	defer collectorFactoriesMu.Unlock()
+
+	defaultState := "disabled"
+	if isDefaultEnabled {
+		defaultState = "enabled"
+	}
+
+	f := &collectorFlag{}
+	flag.Var(f, fmt.Sprintf("collector.%s", name),
+		fmt.Sprintf("Enable the %s collector (default: %s).", name, defaultState))
+
+	collectorFlags[name] = f
+	collectorFactories[name] = registeredCollector{factory: factory, isDefaultEnabled: isDefaultEnabled}
+}
+
+// AvailableCollectors returns the name of every collector that has
+// registered itself, regardless of whether it is currently enabled.
+func AvailableCollectors() []string {
+	collectorFactoriesMu.Lock()
+	defer collectorFactoriesMu.Unlock()
+
+	names := make([]string, 0, len(collectorFactories))
+	for name := range collectorFactories {
+		names = append(names, name)
+	}
+	return names
+}
+
+// EnabledCollectors resolves the --collector.<name> and
+// --collectors.disable-defaults flags into the final set of collector
+// names that should be built.
+func EnabledCollectors() []string {
+	collectorFactoriesMu.Lock()
+	defer collectorFactoriesMu.Unlock()
+
+	var enabled []string
+	for name, rc := range collectorFactories {
+		f := collectorFlags[name]
+		switch {
+		case f.value != nil:
+			if *f.value {
+				enabled = append(enabled, name)
+			}
+		case *disableDefaultCollectors:
+			// no explicit flag and defaults are off: skip
+		case rc.isDefaultEnabled:
+			enabled = append(enabled, name)
+		}
+	}
+	return enabled
+}
+
+// KubeStateCollector wraps every enabled Collector and is the single
+// prometheus.Collector kube-state-metrics registers. Collect fans out to
+// each subcollector in parallel and records scrape instrumentation for it.
+type KubeStateCollector struct {
+	collectors map[string]Collector
+	scope      string
+}
+
+// NewKubeStateCollector builds a Collector for every name in enabled,
+// looking its factory up in the registry populated by registerCollector
+// and scoping each one with cfg.
+func NewKubeStateCollector(kubeClient kubernetes.Interface, cfg CollectorConfig, enabled []string) (*KubeStateCollector, error) {
+	collectorFactoriesMu.Lock()
+	defer collectorFactoriesMu.Unlock()
+
+	collectors := map[string]Collector{}
+	for _, name := range enabled {
+		rc, ok := collectorFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown collector %q", name)
+		}
+
+		c, err := rc.factory(kubeClient, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("creating collector %q: %v", name, err)
+		}
+		if c == nil {
+			glog.Infof("collector %q skipped itself for scope %q", name, cfg.scope())
+			continue
+		}
+		collectors[name] = c
+	}
+	return &KubeStateCollector{collectors: collectors, scope: cfg.scope()}, nil
+}
+
+// Describe implements the prometheus.Collector interface. Subcollector
+// descriptors are dynamic, so only the scrape instrumentation emitted by
+// Collect itself is advertised here.
+func (ksc *KubeStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
+	ch <- lastScrapeErrorDesc
+	ch <- collectorScopeDesc
+}
+
+// Collect implements the prometheus.Collector interface, running every
+// enabled subcollector concurrently and reporting how each one did.
+func (ksc *KubeStateCollector) Collect(ch chan<- prometheus.Metric) {
+	wg := sync.WaitGroup{}
+	wg.Add(len(ksc.collectors))
+	for name, c := range ksc.collectors {
+		go func(name string, c Collector) {
+			defer wg.Done()
+			execute(name, c, ch)
+			ch <- prometheus.MustNewConstMetric(collectorScopeDesc, prometheus.GaugeValue, 1, name, ksc.scope)
+		}(name, c)
+	}
+	wg.Wait()
+}
+
+func execute(name string, c Collector, ch chan<- prometheus.Metric) {
+	begin := time.Now()
+	err := c.Update(ch)
+	duration := time.Since(begin)
+
+	if err != nil {
+		glog.Errorf("collector %q failed after %v: %v", name, duration, err)
+	} else {
+		glog.V(4).Infof("collector %q succeeded after %v", name, duration)
+	}
+
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, boolFloat64(err == nil), name)
+	ch <- prometheus.MustNewConstMetric(lastScrapeErrorDesc, prometheus.GaugeValue, boolFloat64(err != nil), name)
+}
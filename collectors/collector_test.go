@@ -0,0 +1,64 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+func noopFactory(kubeClient kubernetes.Interface, cfg CollectorConfig) (Collector, error) {
+	return nil, nil
+}
+
+func TestEnabledCollectorsRespectsExplicitFlag(t *testing.T) {
+	registerCollector("collectortest-explicit-on", false, noopFactory)
+	registerCollector("collectortest-explicit-off", true, noopFactory)
+
+	if err := collectorFlags["collectortest-explicit-on"].Set("true"); err != nil {
+		t.Fatalf("setting --collector.collectortest-explicit-on: %v", err)
+	}
+	if err := collectorFlags["collectortest-explicit-off"].Set("false"); err != nil {
+		t.Fatalf("setting --collector.collectortest-explicit-off: %v", err)
+	}
+
+	enabled := map[string]bool{}
+	for _, name := range EnabledCollectors() {
+		enabled[name] = true
+	}
+
+	if !enabled["collectortest-explicit-on"] {
+		t.Error("collector disabled by default but explicitly enabled with --collector.<name>=true was not returned by EnabledCollectors()")
+	}
+	if enabled["collectortest-explicit-off"] {
+		t.Error("collector enabled by default but explicitly disabled with --collector.<name>=false was still returned by EnabledCollectors()")
+	}
+}
+
+func TestEnabledCollectorsFallsBackToDefaultWhenFlagUnset(t *testing.T) {
+	registerCollector("collectortest-default-on", true, noopFactory)
+
+	enabled := map[string]bool{}
+	for _, name := range EnabledCollectors() {
+		enabled[name] = true
+	}
+
+	if !enabled["collectortest-default-on"] {
+		t.Error("collector enabled by default with no explicit flag was not returned by EnabledCollectors()")
+	}
+}
@@ -17,21 +17,38 @@ limitations under the License.
 package collectors
 
 import (
+	"fmt"
+
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/context"
 	"k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/fields"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-state-metrics/features"
+)
+
+const (
+	metricNameComponentStatusHealthy = "kube_componentstatus_status_healthy"
+	metricNameComponentStatusMessage = "kube_componentstatus_message"
 )
 
 var (
 	descComponentStatusStatusHealthy = prometheus.NewDesc(
-		"kube_componentstatus_status_healthy",
+		metricNameComponentStatusHealthy,
 		"kube component status healthy status.",
 		[]string{"name", "status"}, nil,
 	)
+
+	// descComponentStatusMessage is gated behind features.ComponentStatusMessage:
+	// condition messages are free text and can carry high-cardinality or
+	// sensitive data, so it ships Alpha/opt-in rather than on by default.
+	descComponentStatusMessage = prometheus.NewDesc(
+		metricNameComponentStatusMessage,
+		"The message reported by a component status condition.",
+		[]string{"name", "status", "message"}, nil,
+	)
 )
 
 type ComponentStatusLister func() (v1.ComponentStatusList, error)
@@ -40,10 +57,26 @@ func (csl ComponentStatusLister) List() (v1.ComponentStatusList, error) {
 	return csl()
 }
 
-func RegisterComponentStatusCollector(registry prometheus.Registerer, kubeClient kubernetes.Interface, namespace string) {
+func init() {
+	registerCollector("componentstatus", true, newComponentStatusCollector)
+}
+
+// newComponentStatusCollector is a CollectorFactory. ComponentStatuses are
+// cluster-scoped, so a CollectorConfig restricted to specific namespaces
+// can't be honored here; rather than watch resources the caller has no
+// business seeing, this collector skips itself.
+func newComponentStatusCollector(kubeClient kubernetes.Interface, cfg CollectorConfig) (Collector, error) {
+	if cfg.namespaceScoped() {
+		glog.Infof("componentstatus is cluster-scoped; skipping it for namespace-restricted scope %v", cfg.Namespaces)
+		return nil, nil
+	}
+
 	client := kubeClient.CoreV1().RESTClient()
 	glog.Infof("collect componentstatuses with %s", client.APIVersion())
-	slw := cache.NewListWatchFromClient(client, "componentstatuses", v1.NamespaceAll, fields.Everything())
+	slw := cache.NewFilteredListWatchFromClient(client, "componentstatuses", v1.NamespaceAll, func(options *metav1.ListOptions) {
+		options.LabelSelector = cfg.LabelSelector
+		options.FieldSelector = cfg.FieldSelector
+	})
 	sinf := cache.NewSharedInformer(slw, &v1.ComponentStatus{}, resyncPeriod)
 
 	componentStatusLister := ComponentStatusLister(func() (componentStatuses v1.ComponentStatusList, err error) {
@@ -53,8 +86,9 @@ func RegisterComponentStatusCollector(registry prometheus.Registerer, kubeClient
 		return componentStatuses, nil
 	})
 
-	registry.MustRegister(&componentStatusCollector{store: componentStatusLister})
 	go sinf.Run(context.Background().Done())
+
+	return &componentStatusCollector{store: componentStatusLister, cfg: cfg}, nil
 }
 
 type componentStatusStore interface {
@@ -64,42 +98,49 @@ type componentStatusStore interface {
 // componentStatusCollector collects metrics about all components in the cluster.
 type componentStatusCollector struct {
 	store componentStatusStore
+	cfg   CollectorConfig
 }
 
-// Describe implements the prometheus.Collector interface.
-func (csc *componentStatusCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- descComponentStatusStatusHealthy
+// Name implements the Collector interface.
+func (csc *componentStatusCollector) Name() string {
+	return "componentstatus"
 }
 
-// Collect implements the prometheus.Collector interface.
-func (csc *componentStatusCollector) Collect(ch chan<- prometheus.Metric) {
+// Update implements the Collector interface.
+func (csc *componentStatusCollector) Update(ch chan<- prometheus.Metric) error {
 	csl, err := csc.store.List()
 	if err != nil {
 		ScrapeErrorTotalMetric.With(prometheus.Labels{"resource": "componentstatus"}).Inc()
-		glog.Errorf("listing component status failed: %s", err)
-		return
+		return fmt.Errorf("listing component status failed: %s", err)
 	}
 
 	ResourcesPerScrapeMetric.With(prometheus.Labels{"resource": "componentstatus"}).Observe(float64(len(csl.Items)))
 	for _, s := range csl.Items {
 		csc.collectComponentStatus(ch, s)
 	}
-	glog.Infof("collected %d componentstatuses", len(csl.Items))
+	glog.V(4).Infof("collected %d componentstatuses", len(csl.Items))
+	return nil
 }
 
 func (csc *componentStatusCollector) collectComponentStatus(ch chan<- prometheus.Metric, s v1.ComponentStatus) {
-	addConstMetric := func(desc *prometheus.Desc, t prometheus.ValueType, v float64, lv ...string) {
+	addConstMetric := func(desc *prometheus.Desc, name string, t prometheus.ValueType, v float64, lv ...string) {
+		if !csc.cfg.AllowDenyList.Allowed(name) {
+			return
+		}
 		lv = append([]string{s.Name}, lv...)
 		ch <- prometheus.MustNewConstMetric(desc, t, v, lv...)
 	}
-	addGauge := func(desc *prometheus.Desc, v float64, lv ...string) {
-		addConstMetric(desc, prometheus.GaugeValue, v, lv...)
+	addGauge := func(desc *prometheus.Desc, name string, v float64, lv ...string) {
+		addConstMetric(desc, name, prometheus.GaugeValue, v, lv...)
 	}
 	for _, p := range s.Conditions {
 		if p.Type == v1.ComponentHealthy {
-			addGauge(descComponentStatusStatusHealthy, boolFloat64(p.Status == v1.ConditionTrue), string(v1.ConditionTrue))
-			addGauge(descComponentStatusStatusHealthy, boolFloat64(p.Status == v1.ConditionFalse), string(v1.ConditionFalse))
-			addGauge(descComponentStatusStatusHealthy, boolFloat64(p.Status == v1.ConditionUnknown), string(v1.ConditionUnknown))
+			addGauge(descComponentStatusStatusHealthy, metricNameComponentStatusHealthy, boolFloat64(p.Status == v1.ConditionTrue), string(v1.ConditionTrue))
+			addGauge(descComponentStatusStatusHealthy, metricNameComponentStatusHealthy, boolFloat64(p.Status == v1.ConditionFalse), string(v1.ConditionFalse))
+			addGauge(descComponentStatusStatusHealthy, metricNameComponentStatusHealthy, boolFloat64(p.Status == v1.ConditionUnknown), string(v1.ConditionUnknown))
+			if features.Enabled(features.ComponentStatusMessage) {
+				addGauge(descComponentStatusMessage, metricNameComponentStatusMessage, 1, string(p.Status), p.Message)
+			}
 			break
 		}
 	}
@@ -0,0 +1,89 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"strings"
+
+	"k8s.io/api/core/v1"
+)
+
+// CollectorConfig is the filtering layer every CollectorFactory receives:
+// it says which namespace(s) and objects a collector should watch, and
+// which of its metrics should actually be emitted.
+type CollectorConfig struct {
+	// Namespaces restricts list-watches to these namespaces. Empty means
+	// every namespace the service account can see. Collectors for
+	// cluster-scoped resources (e.g. ComponentStatus) treat a non-empty
+	// Namespaces as a reason to skip themselves rather than watch
+	// resources the caller has no business seeing.
+	Namespaces []string
+	// LabelSelector and FieldSelector are passed straight through to the
+	// underlying ListWatch.
+	LabelSelector string
+	FieldSelector string
+	// AllowDenyList decides, by metric name, whether a collector should
+	// emit a given metric at all.
+	AllowDenyList AllowDenyList
+}
+
+// namespaceScoped reports whether cfg restricts watches to specific
+// namespaces rather than the whole cluster.
+func (cfg CollectorConfig) namespaceScoped() bool {
+	return len(cfg.Namespaces) > 0
+}
+
+// namespaces resolves cfg.Namespaces to the set of namespaces a namespaced
+// collector should watch, defaulting to every namespace. Collectors watch
+// every entry returned here (one informer per namespace, merged), so this
+// always matches what scope() reports.
+func (cfg CollectorConfig) namespaces() []string {
+	if !cfg.namespaceScoped() {
+		return []string{v1.NamespaceAll}
+	}
+	return cfg.Namespaces
+}
+
+// scope renders cfg.Namespaces for the kube_state_metrics_collector_scope
+// metric.
+func (cfg CollectorConfig) scope() string {
+	if !cfg.namespaceScoped() {
+		return "cluster"
+	}
+	return strings.Join(cfg.Namespaces, ",")
+}
+
+// AllowDenyList decides, by metric name, whether a collector should emit a
+// given metric. An empty AllowDenyList allows everything.
+type AllowDenyList struct {
+	// Allow, when non-empty, is the only set of metric names permitted.
+	Allow map[string]bool
+	// Deny is always checked first: a denied name is never emitted, even
+	// if also present in Allow.
+	Deny map[string]bool
+}
+
+// Allowed reports whether metricName should be emitted.
+func (l AllowDenyList) Allowed(metricName string) bool {
+	if l.Deny[metricName] {
+		return false
+	}
+	if len(l.Allow) > 0 {
+		return l.Allow[metricName]
+	}
+	return true
+}
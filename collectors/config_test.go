@@ -0,0 +1,67 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+)
+
+func TestCollectorConfigNamespacesDefaultsToAll(t *testing.T) {
+	cfg := CollectorConfig{}
+
+	ns := cfg.namespaces()
+	if len(ns) != 1 || ns[0] != v1.NamespaceAll {
+		t.Errorf("namespaces() for an unscoped CollectorConfig = %v, want [%q]", ns, v1.NamespaceAll)
+	}
+	if got, want := cfg.scope(), "cluster"; got != want {
+		t.Errorf("scope() for an unscoped CollectorConfig = %q, want %q", got, want)
+	}
+}
+
+func TestCollectorConfigNamespacesWatchesEveryNamespace(t *testing.T) {
+	cfg := CollectorConfig{Namespaces: []string{"team-a", "team-b"}}
+
+	ns := cfg.namespaces()
+	if len(ns) != 2 || ns[0] != "team-a" || ns[1] != "team-b" {
+		t.Errorf("namespaces() = %v, want [team-a team-b]", ns)
+	}
+
+	// scope() is the only signal an operator has for what's actually being
+	// watched, so it must name every namespace namespaces() returns.
+	if got, want := cfg.scope(), "team-a,team-b"; got != want {
+		t.Errorf("scope() = %q, want %q", got, want)
+	}
+}
+
+func TestAllowDenyListDenyWinsOverAllow(t *testing.T) {
+	l := AllowDenyList{
+		Allow: map[string]bool{"kube_pod_info": true},
+		Deny:  map[string]bool{"kube_pod_info": true},
+	}
+	if l.Allowed("kube_pod_info") {
+		t.Error("Deny should win over Allow for the same metric name")
+	}
+}
+
+func TestAllowDenyListEmptyAllowsEverything(t *testing.T) {
+	l := AllowDenyList{}
+	if !l.Allowed("kube_pod_info") {
+		t.Error("an empty AllowDenyList should allow every metric name")
+	}
+}
@@ -17,18 +17,24 @@ limitations under the License.
 package collectors
 
 import (
+	"fmt"
+
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
-	"golang.org/x/net/context"
 	"k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-state-metrics/features"
+)
+
+const (
+	metricNameLimitRange         = "kube_limitrange"
+	metricNameLimitRangeCreated  = "kube_limitrange_created"
+	metricNameLimitRangeMaxRatio = "kube_limitrange_maxLimitRequestRatio"
 )
 
 var (
 	descLimitRange = prometheus.NewDesc(
-		"kube_limitrange",
+		metricNameLimitRange,
 		"Information about limit range.",
 		[]string{
 			"limitrange",
@@ -40,10 +46,19 @@ var (
 	)
 
 	descLimitRangeCreated = prometheus.NewDesc(
-		"kube_limitrange_created",
+		metricNameLimitRangeCreated,
 		"Unix creation timestamp",
 		[]string{"limitrange", "namespace"}, nil,
 	)
+
+	// descLimitRangeMaxRatio is gated behind features.LimitRangeMaxRatioBreakdown
+	// and breaks maxLimitRequestRatio out of kube_limitrange's constraint
+	// label into its own metric for users migrating off that label scheme.
+	descLimitRangeMaxRatio = prometheus.NewDesc(
+		metricNameLimitRangeMaxRatio,
+		"Maximum limit to request ratio for a resource in a limit range.",
+		[]string{"limitrange", "namespace", "resource", "type"}, nil,
+	)
 )
 
 type LimitRangeLister func() (v1.LimitRangeList, error)
@@ -52,21 +67,25 @@ func (l LimitRangeLister) List() (v1.LimitRangeList, error) {
 	return l()
 }
 
-func RegisterLimitRangeCollector(registry prometheus.Registerer, kubeClient kubernetes.Interface, namespace string) {
-	client := kubeClient.CoreV1().RESTClient()
-	glog.Infof("collect limitrange with %s", client.APIVersion())
-	rqlw := cache.NewListWatchFromClient(client, "limitranges", namespace, fields.Everything())
-	rqinf := cache.NewSharedInformer(rqlw, &v1.LimitRange{}, resyncPeriod)
+func init() {
+	registerCollector("limitrange", true, newLimitRangeCollector)
+}
+
+// newLimitRangeCollector is a CollectorFactory for limitRangeCollector.
+func newLimitRangeCollector(kubeClient kubernetes.Interface, cfg CollectorConfig) (Collector, error) {
+	glog.Infof("collect limitrange with %s", kubeClient.CoreV1().RESTClient().APIVersion())
+	rqinfs := newNamespacedInformers(kubeClient, "limitranges", &v1.LimitRange{}, cfg)
 
 	limitRangeLister := LimitRangeLister(func() (ranges v1.LimitRangeList, err error) {
-		for _, rq := range rqinf.GetStore().List() {
-			ranges.Items = append(ranges.Items, *(rq.(*v1.LimitRange)))
+		for _, rqinf := range rqinfs {
+			for _, rq := range rqinf.GetStore().List() {
+				ranges.Items = append(ranges.Items, *(rq.(*v1.LimitRange)))
+			}
 		}
 		return ranges, nil
 	})
 
-	registry.MustRegister(&limitRangeCollector{store: limitRangeLister})
-	go rqinf.Run(context.Background().Done())
+	return &limitRangeCollector{store: limitRangeLister, cfg: cfg}, nil
 }
 
 type limitRangeStore interface {
@@ -76,60 +95,67 @@ type limitRangeStore interface {
 // limitRangeCollector collects metrics about all limit ranges in the cluster.
 type limitRangeCollector struct {
 	store limitRangeStore
+	cfg   CollectorConfig
 }
 
-// Describe implements the prometheus.Collector interface.
-func (lrc *limitRangeCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- descLimitRange
-	ch <- descLimitRangeCreated
+// Name implements the Collector interface.
+func (lrc *limitRangeCollector) Name() string {
+	return "limitrange"
 }
 
-// Collect implements the prometheus.Collector interface.
-func (lrc *limitRangeCollector) Collect(ch chan<- prometheus.Metric) {
-	limitRangeCollector, err := lrc.store.List()
+// Update implements the Collector interface.
+func (lrc *limitRangeCollector) Update(ch chan<- prometheus.Metric) error {
+	limitRanges, err := lrc.store.List()
 	if err != nil {
 		ScrapeErrorTotalMetric.With(prometheus.Labels{"resource": "limitrange"}).Inc()
-		glog.Errorf("listing limit ranges failed: %s", err)
-		return
+		return fmt.Errorf("listing limit ranges failed: %s", err)
 	}
 
-	ResourcesPerScrapeMetric.With(prometheus.Labels{"resource": "limitrange"}).Observe(float64(len(limitRangeCollector.Items)))
-	for _, rq := range limitRangeCollector.Items {
+	ResourcesPerScrapeMetric.With(prometheus.Labels{"resource": "limitrange"}).Observe(float64(len(limitRanges.Items)))
+	for _, rq := range limitRanges.Items {
 		lrc.collectLimitRange(ch, rq)
 	}
 
-	glog.Infof("collected %d limitranges", len(limitRangeCollector.Items))
+	glog.V(4).Infof("collected %d limitranges", len(limitRanges.Items))
+	return nil
 }
 
 func (lrc *limitRangeCollector) collectLimitRange(ch chan<- prometheus.Metric, rq v1.LimitRange) {
-	addGauge := func(desc *prometheus.Desc, v float64, lv ...string) {
+	addGauge := func(desc *prometheus.Desc, name string, v float64, lv ...string) {
+		if !lrc.cfg.AllowDenyList.Allowed(name) {
+			return
+		}
 		lv = append([]string{rq.Name, rq.Namespace}, lv...)
 		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, lv...)
 	}
 	if !rq.CreationTimestamp.IsZero() {
-		addGauge(descLimitRangeCreated, float64(rq.CreationTimestamp.Unix()))
+		addGauge(descLimitRangeCreated, metricNameLimitRangeCreated, float64(rq.CreationTimestamp.Unix()))
 	}
 
 	rawLimitRanges := rq.Spec.Limits
 	for _, rawLimitRange := range rawLimitRanges {
 		for resource, min := range rawLimitRange.Min {
-			addGauge(descLimitRange, float64(min.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "min")
+			addGauge(descLimitRange, metricNameLimitRange, float64(min.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "min")
 		}
 
 		for resource, max := range rawLimitRange.Max {
-			addGauge(descLimitRange, float64(max.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "max")
+			addGauge(descLimitRange, metricNameLimitRange, float64(max.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "max")
 		}
 
 		for resource, df := range rawLimitRange.Default {
-			addGauge(descLimitRange, float64(df.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "default")
+			addGauge(descLimitRange, metricNameLimitRange, float64(df.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "default")
 		}
 
 		for resource, dfR := range rawLimitRange.DefaultRequest {
-			addGauge(descLimitRange, float64(dfR.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "defaultRequest")
+			addGauge(descLimitRange, metricNameLimitRange, float64(dfR.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "defaultRequest")
 		}
 
 		for resource, mLR := range rawLimitRange.MaxLimitRequestRatio {
-			addGauge(descLimitRange, float64(mLR.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "maxLimitRequestRatio")
+			if features.Enabled(features.LimitRangeMaxRatioBreakdown) {
+				addGauge(descLimitRangeMaxRatio, metricNameLimitRangeMaxRatio, float64(mLR.MilliValue())/1000, string(resource), string(rawLimitRange.Type))
+			} else {
+				addGauge(descLimitRange, metricNameLimitRange, float64(mLR.MilliValue())/1000, string(resource), string(rawLimitRange.Type), "maxLimitRequestRatio")
+			}
 		}
 
 	}
@@ -0,0 +1,218 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	metricNameLimitRangeViolation         = "kube_limitrange_violation"
+	metricNamePodContainerResourceMissing = "kube_pod_container_resource_missing"
+)
+
+var (
+	descLimitRangeViolation = prometheus.NewDesc(
+		metricNameLimitRangeViolation,
+		"Whether a container's resource request or limit violates a LimitRange constraint in its namespace.",
+		[]string{"namespace", "limitrange", "pod", "container", "resource", "constraint"}, nil,
+	)
+
+	descPodContainerResourceMissing = prometheus.NewDesc(
+		metricNamePodContainerResourceMissing,
+		"Whether a container has no resource request or limit set for a resource.",
+		[]string{"namespace", "pod", "container", "resource", "kind"}, nil,
+	)
+)
+
+type PodLister func() (v1.PodList, error)
+
+func (pl PodLister) List() (v1.PodList, error) {
+	return pl()
+}
+
+type podStore interface {
+	List() (v1.PodList, error)
+}
+
+func init() {
+	registerCollector("limitrangeviolation", true, newLimitRangeViolationCollector)
+}
+
+// newLimitRangeViolationCollector is a CollectorFactory. It keeps its own
+// LimitRange list-watches (LimitRanges are cheap and namespace-scoped
+// already) but reuses the shared Pod informers rather than starting a
+// second Pods list-watch alongside the one other pod collectors use.
+func newLimitRangeViolationCollector(kubeClient kubernetes.Interface, cfg CollectorConfig) (Collector, error) {
+	rqinfs := newNamespacedInformers(kubeClient, "limitranges", &v1.LimitRange{}, cfg)
+
+	limitRangeLister := LimitRangeLister(func() (ranges v1.LimitRangeList, err error) {
+		for _, rqinf := range rqinfs {
+			for _, rq := range rqinf.GetStore().List() {
+				ranges.Items = append(ranges.Items, *(rq.(*v1.LimitRange)))
+			}
+		}
+		return ranges, nil
+	})
+
+	podInfs := sharedPodInformers(kubeClient, cfg)
+	podLister := PodLister(func() (pods v1.PodList, err error) {
+		for _, podInf := range podInfs {
+			for _, p := range podInf.GetStore().List() {
+				pods.Items = append(pods.Items, *(p.(*v1.Pod)))
+			}
+		}
+		return pods, nil
+	})
+
+	return &limitRangeViolationCollector{limitRanges: limitRangeLister, pods: podLister, cfg: cfg}, nil
+}
+
+// limitRangeViolationCollector joins LimitRanges against the Pods in the
+// same namespace and reports where a container falls outside what its
+// namespace's LimitRanges allow.
+type limitRangeViolationCollector struct {
+	limitRanges limitRangeStore
+	pods        podStore
+	cfg         CollectorConfig
+}
+
+// Name implements the Collector interface.
+func (c *limitRangeViolationCollector) Name() string {
+	return "limitrangeviolation"
+}
+
+// Update implements the Collector interface.
+func (c *limitRangeViolationCollector) Update(ch chan<- prometheus.Metric) error {
+	limitRanges, err := c.limitRanges.List()
+	if err != nil {
+		ScrapeErrorTotalMetric.With(prometheus.Labels{"resource": "limitrangeviolation"}).Inc()
+		return fmt.Errorf("listing limit ranges failed: %s", err)
+	}
+
+	pods, err := c.pods.List()
+	if err != nil {
+		ScrapeErrorTotalMetric.With(prometheus.Labels{"resource": "limitrangeviolation"}).Inc()
+		return fmt.Errorf("listing pods failed: %s", err)
+	}
+
+	// Cache LimitRanges by namespace so evaluating a pod is O(#limitranges
+	// in its namespace) rather than O(#limitranges in the cluster).
+	limitRangesByNamespace := map[string][]v1.LimitRange{}
+	for _, lr := range limitRanges.Items {
+		limitRangesByNamespace[lr.Namespace] = append(limitRangesByNamespace[lr.Namespace], lr)
+	}
+
+	for _, pod := range pods.Items {
+		nsLimitRanges := limitRangesByNamespace[pod.Namespace]
+		for _, container := range pod.Spec.Containers {
+			c.collectContainer(ch, pod, container, nsLimitRanges)
+		}
+	}
+
+	ResourcesPerScrapeMetric.With(prometheus.Labels{"resource": "limitrangeviolation"}).Observe(float64(len(pods.Items)))
+	glog.V(4).Infof("evaluated %d pods against limitranges in their namespaces", len(pods.Items))
+	return nil
+}
+
+func (c *limitRangeViolationCollector) collectContainer(ch chan<- prometheus.Metric, pod v1.Pod, container v1.Container, limitRanges []v1.LimitRange) {
+	addGauge := func(desc *prometheus.Desc, name string, v float64, lv ...string) {
+		if !c.cfg.AllowDenyList.Allowed(name) {
+			return
+		}
+		lv = append([]string{pod.Namespace}, lv...)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, lv...)
+	}
+
+	// kube_pod_container_resource_missing's candidate set must include cpu
+	// and memory even when a container sets neither request nor limit for
+	// them (that's the unbounded-workload case this metric exists to
+	// catch), plus whatever else the container does set and whatever the
+	// namespace's LimitRanges actually constrain.
+	missingCandidates := unionResourceNames(
+		container.Resources.Requests,
+		container.Resources.Limits,
+		v1.ResourceList{v1.ResourceCPU: resource.Quantity{}, v1.ResourceMemory: resource.Quantity{}},
+	)
+	for _, lr := range limitRanges {
+		for _, item := range lr.Spec.Limits {
+			for name := range unionResourceNames(item.Min, item.Max, item.Default, item.DefaultRequest, item.MaxLimitRequestRatio) {
+				missingCandidates[name] = true
+			}
+		}
+	}
+	for resourceName := range missingCandidates {
+		if _, hasRequest := container.Resources.Requests[resourceName]; !hasRequest {
+			addGauge(descPodContainerResourceMissing, metricNamePodContainerResourceMissing, 1, pod.Name, container.Name, string(resourceName), "request")
+		}
+		if _, hasLimit := container.Resources.Limits[resourceName]; !hasLimit {
+			addGauge(descPodContainerResourceMissing, metricNamePodContainerResourceMissing, 1, pod.Name, container.Name, string(resourceName), "limit")
+		}
+	}
+
+	for _, lr := range limitRanges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type != v1.LimitTypeContainer {
+				continue
+			}
+
+			// A LimitRange item constrains whatever resources it names in
+			// Min/Max/Default/DefaultRequest/MaxLimitRequestRatio, which
+			// may be any v1.ResourceName, not just cpu/memory.
+			for resourceName := range unionResourceNames(item.Min, item.Max, item.Default, item.DefaultRequest, item.MaxLimitRequestRatio) {
+				request, hasRequest := container.Resources.Requests[resourceName]
+				limit, hasLimit := container.Resources.Limits[resourceName]
+
+				if min, ok := item.Min[resourceName]; ok && hasRequest && request.Cmp(min) < 0 {
+					addGauge(descLimitRangeViolation, metricNameLimitRangeViolation, 1, lr.Name, pod.Name, container.Name, string(resourceName), "min")
+				}
+				if max, ok := item.Max[resourceName]; ok && hasLimit && limit.Cmp(max) > 0 {
+					addGauge(descLimitRangeViolation, metricNameLimitRangeViolation, 1, lr.Name, pod.Name, container.Name, string(resourceName), "max")
+				}
+				if ratio, ok := item.MaxLimitRequestRatio[resourceName]; ok && hasRequest && hasLimit && request.MilliValue() > 0 {
+					actual := float64(limit.MilliValue()) / float64(request.MilliValue())
+					if actual > float64(ratio.MilliValue())/1000 {
+						addGauge(descLimitRangeViolation, metricNameLimitRangeViolation, 1, lr.Name, pod.Name, container.Name, string(resourceName), "maxLimitRequestRatio")
+					}
+				}
+				if _, ok := item.Default[resourceName]; ok && !hasLimit {
+					addGauge(descLimitRangeViolation, metricNameLimitRangeViolation, 1, lr.Name, pod.Name, container.Name, string(resourceName), "missingDefault")
+				}
+				if _, ok := item.DefaultRequest[resourceName]; ok && !hasRequest {
+					addGauge(descLimitRangeViolation, metricNameLimitRangeViolation, 1, lr.Name, pod.Name, container.Name, string(resourceName), "missingDefaultRequest")
+				}
+			}
+		}
+	}
+}
+
+// unionResourceNames returns the set of resource names present in any of lists.
+func unionResourceNames(lists ...v1.ResourceList) map[v1.ResourceName]bool {
+	names := map[v1.ResourceName]bool{}
+	for _, list := range lists {
+		for name := range list {
+			names[name] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// collectMissingResourceLabels runs collectContainer and returns the set of
+// "resource" label values reported against descPodContainerResourceMissing.
+func collectMissingResourceLabels(t *testing.T, container v1.Container, limitRanges []v1.LimitRange) map[string]bool {
+	t.Helper()
+
+	c := &limitRangeViolationCollector{cfg: CollectorConfig{}}
+	pod := v1.Pod{}
+	pod.Namespace = "default"
+	pod.Name = "pod"
+
+	ch := make(chan prometheus.Metric, 64)
+	c.collectContainer(ch, pod, container, limitRanges)
+	close(ch)
+
+	missing := map[string]bool{}
+	for m := range ch {
+		if m.Desc() != descPodContainerResourceMissing {
+			continue
+		}
+		var d dto.Metric
+		if err := m.Write(&d); err != nil {
+			t.Fatalf("writing metric: %v", err)
+		}
+		for _, lp := range d.Label {
+			if lp.GetName() == "resource" {
+				missing[lp.GetValue()] = true
+			}
+		}
+	}
+	return missing
+}
+
+// TestCollectContainerReportsMissingCPUAndMemoryWithNoResourcesSet guards
+// against the unbounded-workload case kube_pod_container_resource_missing
+// exists to catch: a container with neither Requests nor Limits set must
+// still produce cpu/memory entries even though neither resource name
+// appears in the container's own (empty) resource maps.
+func TestCollectContainerReportsMissingCPUAndMemoryWithNoResourcesSet(t *testing.T) {
+	container := v1.Container{Name: "app"}
+
+	missing := collectMissingResourceLabels(t, container, nil)
+
+	if !missing["cpu"] {
+		t.Error("container with no requests/limits set should report kube_pod_container_resource_missing for cpu")
+	}
+	if !missing["memory"] {
+		t.Error("container with no requests/limits set should report kube_pod_container_resource_missing for memory")
+	}
+}
+
+// TestCollectContainerReportsMissingForLimitRangeResource checks that a
+// resource named only by a namespace LimitRange (not cpu/memory, and not
+// already present on the container) still shows up as missing.
+func TestCollectContainerReportsMissingForLimitRangeResource(t *testing.T) {
+	container := v1.Container{Name: "app"}
+	limitRanges := []v1.LimitRange{{
+		Spec: v1.LimitRangeSpec{
+			Limits: []v1.LimitRangeItem{{
+				Type: v1.LimitTypeContainer,
+				Min:  v1.ResourceList{"ephemeral-storage": resource.MustParse("1Gi")},
+			}},
+		},
+	}}
+
+	missing := collectMissingResourceLabels(t, container, limitRanges)
+
+	if !missing["ephemeral-storage"] {
+		t.Error("resource named only by a namespace LimitRange should still report kube_pod_container_resource_missing")
+	}
+}
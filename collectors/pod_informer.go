@@ -0,0 +1,64 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/glog"
+	"golang.org/x/net/context"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	podInformersMu sync.Mutex
+	podInformers   = map[string]cache.SharedInformer{}
+)
+
+// sharedPodInformers returns one SharedInformer per namespace in
+// cfg.namespaces(), starting each on first use and keyed by namespace plus
+// selectors. Every collector that needs pod state for the same namespace
+// and selectors shares the same informer instead of issuing its own
+// list-watch against the Pods endpoint.
+func sharedPodInformers(kubeClient kubernetes.Interface, cfg CollectorConfig) []cache.SharedInformer {
+	podInformersMu.Lock()
+	defer podInformersMu.Unlock()
+
+	namespaces := cfg.namespaces()
+	informers := make([]cache.SharedInformer, 0, len(namespaces))
+	for _, ns := range namespaces {
+		key := fmt.Sprintf("%s|%s|%s", ns, cfg.LabelSelector, cfg.FieldSelector)
+		sinf, ok := podInformers[key]
+		if !ok {
+			client := kubeClient.CoreV1().RESTClient()
+			glog.Infof("collect pods with %s", client.APIVersion())
+			plw := cache.NewFilteredListWatchFromClient(client, "pods", ns, func(options *metav1.ListOptions) {
+				options.LabelSelector = cfg.LabelSelector
+				options.FieldSelector = cfg.FieldSelector
+			})
+			sinf = cache.NewSharedInformer(plw, &v1.Pod{}, resyncPeriod)
+			podInformers[key] = sinf
+			go sinf.Run(context.Background().Done())
+		}
+		informers = append(informers, sinf)
+	}
+	return informers
+}
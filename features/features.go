@@ -0,0 +1,64 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package features declares the named feature gates collectors consult
+// before emitting an Alpha or Beta metric, so new or cardinality-heavy
+// descriptors can be opted into with --feature-gates instead of a fork.
+package features
+
+import (
+	"flag"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/component-base/featuregate"
+)
+
+const (
+	// ComponentStatusMessage adds a "message" label carrying the raw
+	// condition message to kube_componentstatus_message.
+	ComponentStatusMessage featuregate.Feature = "ComponentStatusMessage"
+
+	// LimitRangeMaxRatioBreakdown emits kube_limitrange_maxLimitRequestRatio
+	// as its own metric instead of folding the ratio into the generic
+	// kube_limitrange constraint label.
+	LimitRangeMaxRatioBreakdown featuregate.Feature = "LimitRangeMaxRatioBreakdown"
+)
+
+// defaultFeatureGates is the registry's source of truth: every gate a
+// collector may check must be declared here along with its graduation
+// stage so --feature-gates=Foo=true can be validated against it.
+var defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	ComponentStatusMessage:      {Default: false, PreRelease: featuregate.Alpha},
+	LimitRangeMaxRatioBreakdown: {Default: false, PreRelease: featuregate.Alpha},
+}
+
+// Gates is the process-wide feature gate registry. It registers its own
+// --feature-gates flag, the same way collectors.registerCollector
+// self-registers a --collector.<name> flag, so nothing outside this
+// package has to wire it up.
+var Gates featuregate.MutableFeatureGate = featuregate.NewFeatureGate()
+
+func init() {
+	runtime.Must(Gates.Add(defaultFeatureGates))
+	flag.Var(Gates.(flag.Value), "feature-gates", "A set of key=value pairs that enable or disable alpha/experimental metrics. Options are:\n"+strings.Join(Gates.KnownFeatures(), "\n"))
+}
+
+// Enabled reports whether f is turned on, either by its default stage or
+// by an explicit --feature-gates=f=true/false.
+func Enabled(f featuregate.Feature) bool {
+	return Gates.Enabled(f)
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestEnabledDefaultsToGateDefault(t *testing.T) {
+	if Enabled(ComponentStatusMessage) {
+		t.Error("ComponentStatusMessage defaults to false but Enabled() reported true")
+	}
+}
+
+func TestFeatureGatesFlagIsSelfRegistered(t *testing.T) {
+	f := flag.Lookup("feature-gates")
+	if f == nil {
+		t.Fatal("features package did not self-register a --feature-gates flag; nothing can enable ComponentStatusMessage or LimitRangeMaxRatioBreakdown from the command line")
+	}
+
+	if err := f.Value.Set("ComponentStatusMessage=true"); err != nil {
+		t.Fatalf("setting --feature-gates=ComponentStatusMessage=true: %v", err)
+	}
+	defer f.Value.Set("ComponentStatusMessage=false")
+
+	if !Enabled(ComponentStatusMessage) {
+		t.Error("ComponentStatusMessage=true via --feature-gates did not enable the gate")
+	}
+}